@@ -0,0 +1,89 @@
+package smtpd
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/jh125486/smtpd/sasl"
+)
+
+// AuthUser identifies a client that has completed SASL authentication,
+// as populated by DoAuth.
+type AuthUser string
+
+// ErrAuthAlreadySucceeded is returned by DoAuth when AUTH is issued
+// again after a successful exchange on the same connection.
+var ErrAuthAlreadySucceeded = errors.New("smtpd: authentication already succeeded")
+
+// ErrAuthRequiresTLS is returned by DoAuth when RequireTLSForAuth is set
+// and the client attempts AUTH before STARTTLS.
+var ErrAuthRequiresTLS = errors.New("smtpd: AUTH requires STARTTLS first")
+
+// DoAuth drives the SASL challenge/response exchange for mech to
+// completion: it honours an initial response supplied on the AUTH
+// command line itself (AUTH PLAIN <b64>), exchanges any further
+// challenges as base64-encoded 334 continuations, and populates c.User
+// on success. lookup resolves mech (case-sensitive, as advertised in
+// EHLO's AUTH line) to a configured sasl.Mechanism.
+func (c *Conn) DoAuth(mech string, initialResponse string, lookup func(mech string) (sasl.Mechanism, bool)) (AuthUser, error) {
+	if c.User != "" {
+		return "", ErrAuthAlreadySucceeded
+	}
+	if c.RequireTLSForAuth && !c.IsTLS {
+		return "", ErrAuthRequiresTLS
+	}
+
+	m, ok := lookup(mech)
+	if !ok {
+		return "", fmt.Errorf("smtpd: unsupported AUTH mechanism %q", mech)
+	}
+
+	var response []byte
+	if initialResponse != "" {
+		decoded, err := base64.StdEncoding.DecodeString(initialResponse)
+		if err != nil {
+			return "", fmt.Errorf("smtpd: invalid initial response: %w", err)
+		}
+		response = decoded
+	}
+
+	for {
+		challenge, done, err := m.Next(response)
+		if err != nil {
+			return "", err
+		}
+		if done {
+			break
+		}
+
+		if err := c.WriteSMTP(334, base64.StdEncoding.EncodeToString(challenge)); err != nil {
+			return "", err
+		}
+
+		line, err := c.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		if line == "*" {
+			return "", fmt.Errorf("smtpd: AUTH cancelled by client")
+		}
+
+		response, err = base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			return "", fmt.Errorf("smtpd: invalid base64 response: %w", err)
+		}
+	}
+
+	identity := mech
+	if id, ok := m.(sasl.Identifier); ok {
+		identity = id.Identity()
+	}
+
+	user := AuthUser(identity)
+	c.lock.Lock()
+	c.User = user
+	c.state = StateAuth
+	c.lock.Unlock()
+	return user, nil
+}
@@ -0,0 +1,59 @@
+package smtpd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Capabilities holds the set of ESMTP extensions a Conn advertises in
+// response to EHLO. Zero values mean "not advertised" for every field
+// except the boolean switches, which default to off.
+type Capabilities struct {
+	// MaxSize advertises SIZE <n> (RFC 1870); zero omits the capability.
+	MaxSize int64
+	// EightBitMIME advertises 8BITMIME (RFC 6152).
+	EightBitMIME bool
+	// SMTPUTF8 advertises SMTPUTF8 (RFC 6531).
+	SMTPUTF8 bool
+	// Pipelining advertises PIPELINING (RFC 2920).
+	Pipelining bool
+	// StartTLS advertises STARTTLS (RFC 3207).
+	StartTLS bool
+	// AuthMechanisms advertises AUTH <mechs> (RFC 4954); empty hides AUTH.
+	AuthMechanisms []string
+	// EnhancedStatusCodes advertises ENHANCEDSTATUSCODES (RFC 2034).
+	EnhancedStatusCodes bool
+	// Chunking advertises CHUNKING (RFC 3030).
+	Chunking bool
+}
+
+// Lines renders the capability set as the EHLO keyword lines that follow
+// the greeting, in a stable and conventional order.
+func (caps Capabilities) Lines() []string {
+	var lines []string
+	if caps.MaxSize > 0 {
+		lines = append(lines, fmt.Sprintf("SIZE %d", caps.MaxSize))
+	}
+	if caps.EightBitMIME {
+		lines = append(lines, "8BITMIME")
+	}
+	if caps.SMTPUTF8 {
+		lines = append(lines, "SMTPUTF8")
+	}
+	if caps.Pipelining {
+		lines = append(lines, "PIPELINING")
+	}
+	if caps.StartTLS {
+		lines = append(lines, "STARTTLS")
+	}
+	if len(caps.AuthMechanisms) > 0 {
+		lines = append(lines, "AUTH "+strings.Join(caps.AuthMechanisms, " "))
+	}
+	if caps.EnhancedStatusCodes {
+		lines = append(lines, "ENHANCEDSTATUSCODES")
+	}
+	if caps.Chunking {
+		lines = append(lines, "CHUNKING")
+	}
+	return lines
+}
@@ -2,11 +2,9 @@ package smtpd
 
 import (
 	"bufio"
-	"fmt"
 	"io"
 	"net"
 	"net/mail"
-	"net/textproto"
 	"strings"
 	"sync"
 	"time"
@@ -19,66 +17,109 @@ type Conn struct {
 	net.Conn
 
 	// Track some mutable for this connection
-	IsTLS    bool
-	Errors   []error
-	User     AuthUser
-	FromAddr *mail.Address
-	ToAddr   []*mail.Address
+	IsTLS      bool
+	Errors     []error
+	User       AuthUser
+	FromAddr   *mail.Address
+	ToAddr     []*mail.Address
+	MailParams *MailParams
+	ClientName string
+	QueueID    string
 
 	// Configuration options
-	MaxSize      int64
-	ReadTimeout  time.Duration
-	WriteTimeout time.Duration
+	MaxSize           int64
+	MaxCommandLine    int
+	MaxReplyLine      int
+	MaxDataLine       int
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	Capabilities      Capabilities
+	RequireTLSForAuth bool
 
 	// internal state
 	lock        sync.Mutex
 	transaction int
+	state       State
 
-	asTextProto sync.Once
-	textProto   *textproto.Conn
+	asReader  sync.Once
+	bufReader *bufio.Reader
 }
 
-// tp returns a textproto wrapper for this connection
-func (c *Conn) tp() *textproto.Conn {
-	c.asTextProto.Do(func() {
-		c.textProto = textproto.NewConn(c)
-		if c.MaxSize > 0 {
-			c.textProto.Reader = *textproto.NewReader(bufio.NewReader(io.LimitReader(c, c.MaxSize)))
-		}
+// reader returns the *bufio.Reader for this connection. It owns a single
+// buffer for the lifetime of the connection; ReadSMTP/ReadLine/ReadData
+// read through it via readBoundedLine so an over-long line fails fast
+// with a *LineTooLongError rather than growing the read buffer without
+// bound. PIPELINING relies on this: a client's batched commands land in
+// bufReader once and are served from there without blocking on the
+// socket again.
+func (c *Conn) reader() *bufio.Reader {
+	c.asReader.Do(func() {
+		c.rebuildReader(c)
 	})
-	return c.textProto
+	return c.bufReader
+}
+
+// rebuildReader (re)builds the buffered reader on top of src. It is
+// called once lazily by reader(), and again by StartTLS after the
+// underlying net.Conn is replaced with the TLS connection.
+func (c *Conn) rebuildReader(src io.Reader) {
+	var r io.Reader = src
+	if c.MaxSize > 0 {
+		r = io.LimitReader(r, c.MaxSize)
+	}
+	c.bufReader = bufio.NewReader(r)
 }
 
-// StartTX starts a new MAIL transaction
-func (c *Conn) StartTX(from *mail.Address) error {
+// StartTX starts a new MAIL transaction. params may be nil if the client
+// sent no ESMTP parameters on MAIL FROM.
+func (c *Conn) StartTX(from *mail.Address, params *MailParams) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	if c.transaction != 0 {
 		return ErrTransaction
 	}
 	c.transaction = int(time.Now().UnixNano())
 	c.FromAddr = from
+	c.MailParams = params
+	c.state = StateMail
 	return nil
 }
 
 // EndTX closes off a MAIL transaction and returns a message object
 func (c *Conn) EndTX() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	if c.transaction == 0 {
 		return ErrTransaction
 	}
 	c.transaction = 0
+	c.state = StateGreeted
 	return nil
 }
 
+// Reset clears the in-flight MAIL transaction, per RFC 5321 §4.1.1.5.
+// Authentication is not part of the transaction: per RFC 4954 §4, a
+// successful AUTH survives RSET, so c.User and a StateAuth connection
+// state are left untouched.
 func (c *Conn) Reset() {
-	c.User = nil
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	c.FromAddr = nil
 	c.ToAddr = make([]*mail.Address, 0)
+	c.MailParams = nil
 	c.transaction = 0
+	if c.state > StateGreeted && c.state != StateAuth {
+		c.state = StateGreeted
+	}
 }
 
-// ReadSMTP pulls a single SMTP command line (ending in a carriage return + newline)
+// ReadSMTP pulls a single SMTP command line (ending in a carriage return + newline).
+// If the verb is out of sequence for the connection's current State, it
+// returns ErrBadSequence alongside the parsed verb/args so the caller can
+// still log it and reply 503 without re-parsing the line.
 func (c *Conn) ReadSMTP() (string, string, error) {
 	c.SetReadDeadline(time.Now().Add(c.ReadTimeout))
-	if line, err := c.tp().ReadLine(); err == nil {
+	if line, err := readBoundedLine(c.reader(), c.maxCommandLine()); err == nil {
 		var args string
 		command := strings.SplitN(line, " ", 2)
 
@@ -87,37 +128,47 @@ func (c *Conn) ReadSMTP() (string, string, error) {
 			args = command[1]
 		}
 
+		if err := c.Transition(verb); err != nil {
+			return verb, args, err
+		}
+
 		return verb, args, nil
 	} else {
-		return "", "", err
+		return "", "", classifyErr(err)
 	}
 }
 
 // ReadLine reads a single line from the client
 func (c *Conn) ReadLine() (string, error) {
 	c.SetReadDeadline(time.Now().Add(c.ReadTimeout))
-	return c.tp().ReadLine()
+	line, err := readBoundedLine(c.reader(), c.maxCommandLine())
+	return line, classifyErr(err)
 }
 
-// ReadData brokers the special case of SMTP data messages
+// ReadData brokers the special case of SMTP data messages: a sequence of
+// lines, transparency-unstuffed per RFC 5321 §4.5.2, terminated by a
+// line containing a single ".". Each line is capped at MaxDataLine
+// bytes, distinct from the MaxCommandLine cap ReadSMTP/ReadLine enforce.
+// Lines are rejoined with CRLF, matching the wire format they arrived
+// in, so the result stays RFC 5322-consistent when ReadMessage prepends
+// a CRLF-terminated Received header.
 func (c *Conn) ReadData() (string, error) {
-	c.SetReadDeadline(time.Now().Add(c.ReadTimeout))
-	lines, err := c.tp().ReadDotLines()
-	return strings.Join(lines, "\n"), err
-}
-
-// WriteSMTP writes a general SMTP line
-func (c *Conn) WriteSMTP(code int, message string) error {
-	c.SetWriteDeadline(time.Now().Add(c.WriteTimeout))
-	_, err := c.Write([]byte(fmt.Sprintf("%v %v", code, message) + "\r\n"))
-	return err
-}
-
-// WriteEHLO writes an EHLO line, see https://tools.ietf.org/html/rfc2821#section-4.1.1.1
-func (c *Conn) WriteEHLO(message string) error {
-	c.SetWriteDeadline(time.Now().Add(c.WriteTimeout))
-	_, err := c.Write([]byte(fmt.Sprintf("250-%v", message) + "\r\n"))
-	return err
+	var lines []string
+	for {
+		c.SetReadDeadline(time.Now().Add(c.ReadTimeout))
+		line, err := readBoundedLine(c.reader(), c.maxDataLine())
+		if err != nil {
+			return "", classifyErr(err)
+		}
+		if line == "." {
+			break
+		}
+		if strings.HasPrefix(line, "..") {
+			line = line[1:]
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\r\n"), nil
 }
 
 // WriteOK is a convenience function for sending the default OK response
@@ -0,0 +1,16 @@
+package smtpd
+
+import "errors"
+
+// ErrTransaction is returned by StartTX and EndTX when they are called
+// out of turn against the in-flight MAIL transaction.
+var ErrTransaction = errors.New("smtpd: transaction already in progress")
+
+// ErrBadSequence is returned by Transition when a command arrives out of
+// the order RFC 5321 requires (e.g. RCPT before MAIL). Callers should
+// reply with a 503 "bad sequence of commands".
+var ErrBadSequence = errors.New("smtpd: bad sequence of commands")
+
+// ErrTLSAlreadyNegotiated is returned by StartTLS when TLS has already
+// been negotiated on this connection.
+var ErrTLSAlreadyNegotiated = errors.New("smtpd: TLS already negotiated")
@@ -0,0 +1,123 @@
+package smtpd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+const (
+	// DefaultMaxCommandLine is the default cap on a single command line,
+	// per RFC 5321 §4.5.3.1.4.
+	DefaultMaxCommandLine = 512
+	// DefaultMaxReplyLine is the default cap on a single reply line this
+	// package writes.
+	DefaultMaxReplyLine = 512
+	// DefaultMaxDataLine is the default cap on a single line of DATA.
+	DefaultMaxDataLine = 1000
+)
+
+// ErrTimeout is returned in place of the underlying net.Error when a
+// read deadline expires while waiting on the client.
+var ErrTimeout = errors.New("smtpd: timed out waiting on client")
+
+// ErrClosed is returned in place of the underlying error when the
+// client closes the connection mid-read.
+var ErrClosed = errors.New("smtpd: connection closed by client")
+
+// ErrLineTooLong is the sentinel wrapped by LineTooLongError; test
+// against it with errors.Is.
+var ErrLineTooLong = errors.New("smtpd: line too long")
+
+// LineTooLongError reports that a line from the client exceeded Max
+// bytes without a terminator, so the caller can reply 500 5.5.6 "line
+// too long" instead of tearing down the connection.
+type LineTooLongError struct {
+	Max int
+}
+
+func (e *LineTooLongError) Error() string {
+	return fmt.Sprintf("smtpd: line exceeds %d bytes", e.Max)
+}
+
+func (e *LineTooLongError) Unwrap() error {
+	return ErrLineTooLong
+}
+
+// classifyErr converts a raw I/O error from the underlying connection
+// into one of the package's typed errors, so callers can distinguish an
+// idle-timeout drop from a client abort.
+func classifyErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	var nerr net.Error
+	if errors.As(err, &nerr) && nerr.Timeout() {
+		return ErrTimeout
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrClosed
+	}
+	return err
+}
+
+// readBoundedLine reads one CRLF-terminated line from r, capped at max
+// bytes, and returns *LineTooLongError if it isn't. It drives
+// bufio.Reader.ReadLine directly rather than going through
+// net/textproto's Reader: textproto's ReadLine transparently stitches
+// ReadLine's isPrefix continuations into one unbounded string, which
+// defeats a length cap outright, and a reader that instead tries to
+// inject the error into the byte stream runs into the same problem from
+// the other side — bufio discards a stored error whenever it has any
+// line bytes to hand back, so the error either gets lost or resurfaces
+// on a later, unrelated line. Watching isPrefix ourselves avoids both.
+func readBoundedLine(r *bufio.Reader, max int) (string, error) {
+	var (
+		line     []byte
+		tooLong  bool
+		isPrefix = true
+	)
+	for isPrefix {
+		var (
+			chunk []byte
+			err   error
+		)
+		chunk, isPrefix, err = r.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		if len(line)+len(chunk) > max {
+			tooLong = true
+		}
+		if !tooLong {
+			line = append(line, chunk...)
+		}
+	}
+	if tooLong {
+		return "", &LineTooLongError{Max: max}
+	}
+	return string(line), nil
+}
+
+func (c *Conn) maxCommandLine() int {
+	if c.MaxCommandLine > 0 {
+		return c.MaxCommandLine
+	}
+	return DefaultMaxCommandLine
+}
+
+func (c *Conn) maxReplyLine() int {
+	if c.MaxReplyLine > 0 {
+		return c.MaxReplyLine
+	}
+	return DefaultMaxReplyLine
+}
+
+func (c *Conn) maxDataLine() int {
+	if c.MaxDataLine > 0 {
+		return c.MaxDataLine
+	}
+	return DefaultMaxDataLine
+}
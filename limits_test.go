@@ -0,0 +1,65 @@
+package smtpd
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadBoundedLineExactMaxAccepted(t *testing.T) {
+	// RFC 5321 §4.5.3.1.4: a command line of exactly max bytes,
+	// including the CRLF, MUST be accepted.
+	content := "MAIL FROM:<" + strings.Repeat("a", DefaultMaxCommandLine-2-len("MAIL FROM:<")-1) + ">"
+	if got := len(content) + 2; got != DefaultMaxCommandLine {
+		t.Fatalf("test setup: content+CRLF = %d, want %d", got, DefaultMaxCommandLine)
+	}
+
+	r := bufio.NewReader(strings.NewReader(content + "\r\nNOOP\r\n"))
+
+	line, err := readBoundedLine(r, DefaultMaxCommandLine)
+	if err != nil {
+		t.Fatalf("exact-max line rejected: %v", err)
+	}
+	if line != content {
+		t.Fatalf("got %q", line)
+	}
+
+	line, err = readBoundedLine(r, DefaultMaxCommandLine)
+	if err != nil {
+		t.Fatalf("following line rejected: %v", err)
+	}
+	if line != "NOOP" {
+		t.Fatalf("got %q", line)
+	}
+}
+
+func TestReadBoundedLineOverMaxRejected(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("NOOP " + strings.Repeat("a", 600) + "\r\n"))
+
+	_, err := readBoundedLine(r, DefaultMaxCommandLine)
+
+	var tooLong *LineTooLongError
+	if !errors.As(err, &tooLong) {
+		t.Fatalf("expected *LineTooLongError, got %v", err)
+	}
+	if tooLong.Max != DefaultMaxCommandLine {
+		t.Fatalf("got Max=%d, want %d", tooLong.Max, DefaultMaxCommandLine)
+	}
+}
+
+func TestReadBoundedLinePipelinedBatchNotTruncated(t *testing.T) {
+	// A batch of small pipelined commands landing in one underlying
+	// Read must be bounded per logical line, not per read.
+	r := bufio.NewReader(strings.NewReader("NOOP\r\nNOOP\r\nNOOP\r\n"))
+
+	for i := 0; i < 3; i++ {
+		line, err := readBoundedLine(r, DefaultMaxCommandLine)
+		if err != nil {
+			t.Fatalf("cmd %d: unexpected error: %v", i, err)
+		}
+		if line != "NOOP" {
+			t.Fatalf("cmd %d: got %q", i, line)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package smtpd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MailParams holds the ESMTP parameters a client may attach to a MAIL
+// FROM command, per RFC 1869 and the extensions layered on top of it.
+type MailParams struct {
+	// Size is the declared message size from SIZE=<n> (RFC 1870); zero if absent.
+	Size int64
+	// Body is the BODY= value, e.g. "7BIT" or "8BITMIME" (RFC 6152).
+	Body string
+	// SMTPUTF8 reports whether the client sent the SMTPUTF8 parameter (RFC 6531).
+	SMTPUTF8 bool
+	// Auth is the AUTH= value (RFC 4954), or "" if absent or "<>".
+	Auth string
+}
+
+// ParseMailParams parses the trailing ESMTP parameters of a MAIL FROM
+// command, e.g. "SIZE=1024 BODY=8BITMIME SMTPUTF8". Unrecognized
+// parameters are ignored so that future extensions don't break callers.
+func ParseMailParams(args string) (*MailParams, error) {
+	params := &MailParams{}
+	for _, field := range strings.Fields(args) {
+		key, value, _ := strings.Cut(field, "=")
+		switch strings.ToUpper(key) {
+		case "SIZE":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("smtpd: invalid SIZE parameter %q: %w", value, err)
+			}
+			params.Size = size
+		case "BODY":
+			params.Body = strings.ToUpper(value)
+		case "SMTPUTF8":
+			params.SMTPUTF8 = true
+		case "AUTH":
+			if value != "<>" {
+				params.Auth = value
+			}
+		}
+	}
+	return params, nil
+}
+
+// ExceedsMaxSize reports whether the declared SIZE parameter is larger
+// than max, so handlers can reject oversized messages with a 552 before
+// reading DATA. A max of zero or a missing SIZE parameter is never
+// considered too large.
+func (p *MailParams) ExceedsMaxSize(max int64) bool {
+	return max > 0 && p != nil && p.Size > max
+}
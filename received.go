@@ -0,0 +1,70 @@
+package smtpd
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base32"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// queueIDEncoding renders queue ids as uppercase base32, unpadded.
+var queueIDEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newQueueID generates a random 12-character queue id, used to
+// correlate a message's Received trace header with the server's logs.
+func newQueueID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return queueIDEncoding.EncodeToString(buf)[:12], nil
+}
+
+// ReadMessage reads the DATA section via ReadData and prepends a
+// Received: trace header, as RFC 5321 §4.4 requires every relaying or
+// delivering SMTP server to do. It also assigns c.QueueID if this is the
+// first message read on the connection, so log lines and the header
+// agree.
+func (c *Conn) ReadMessage(hostname string) (io.Reader, error) {
+	data, err := c.ReadData()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.QueueID == "" {
+		id, err := newQueueID()
+		if err != nil {
+			return nil, err
+		}
+		c.QueueID = id
+	}
+
+	return strings.NewReader(c.receivedHeader(hostname) + data), nil
+}
+
+// receivedHeader renders the Received: header for the in-flight
+// transaction.
+func (c *Conn) receivedHeader(hostname string) string {
+	keyword := "ESMTP"
+	var cipher string
+	if c.IsTLS {
+		keyword = "ESMTPS"
+		if tlsConn, ok := c.Conn.(*tls.Conn); ok {
+			cipher = fmt.Sprintf(" (%v)", tls.CipherSuiteName(tlsConn.ConnectionState().CipherSuite))
+		}
+	}
+
+	var forClause string
+	if len(c.ToAddr) == 1 {
+		forClause = fmt.Sprintf(" for <%v>", c.ToAddr[0].Address)
+	}
+
+	return fmt.Sprintf(
+		"Received: from %v (%v)\r\n\tby %v with %v%v id %v%v;\r\n\t%v\r\n",
+		c.ClientName, c.RemoteAddr(), hostname, keyword, cipher, c.QueueID, forClause,
+		time.Now().Format(time.RFC1123Z),
+	)
+}
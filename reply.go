@@ -0,0 +1,71 @@
+package smtpd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Reply is one line of a (possibly multi-line) SMTP response: a status
+// code, an optional RFC 2034 enhanced status code (e.g. "2.1.0"), and
+// the message text.
+type Reply struct {
+	Code         int
+	EnhancedCode string
+	Message      string
+}
+
+// WriteReplies formats replies into the standard multi-line framing —
+// every line but the last prefixed "<code>-", the last "<code> " — and
+// writes them with a single Write call, so PIPELINING clients always
+// see a whole response land in one TCP segment rather than several.
+func (c *Conn) WriteReplies(replies ...Reply) error {
+	c.SetWriteDeadline(time.Now().Add(c.WriteTimeout))
+
+	var b strings.Builder
+	for i, r := range replies {
+		sep := "-"
+		if i == len(replies)-1 {
+			sep = " "
+		}
+		message := r.Message
+		if r.EnhancedCode != "" {
+			message = r.EnhancedCode + " " + message
+		}
+		line := fmt.Sprintf("%d%s%s\r\n", r.Code, sep, message)
+		if len(line) > c.maxReplyLine() {
+			return &LineTooLongError{Max: c.maxReplyLine()}
+		}
+		b.WriteString(line)
+	}
+
+	_, err := c.Write([]byte(b.String()))
+	return err
+}
+
+// WriteSMTP writes a general SMTP line
+func (c *Conn) WriteSMTP(code int, message string) error {
+	return c.WriteReplies(Reply{Code: code, Message: message})
+}
+
+// WriteEHLO writes an EHLO continuation line, see https://tools.ietf.org/html/rfc2821#section-4.1.1.1
+func (c *Conn) WriteEHLO(message string) error {
+	c.SetWriteDeadline(time.Now().Add(c.WriteTimeout))
+	line := fmt.Sprintf("250-%s\r\n", message)
+	if len(line) > c.maxReplyLine() {
+		return &LineTooLongError{Max: c.maxReplyLine()}
+	}
+	_, err := c.Write([]byte(line))
+	return err
+}
+
+// WriteEHLOResponse writes the full multi-line EHLO response: greeting
+// first, then one line per entry in caps, see https://tools.ietf.org/html/rfc1869.
+func (c *Conn) WriteEHLOResponse(greeting string, caps []string) error {
+	replies := make([]Reply, 0, len(caps)+1)
+	replies = append(replies, Reply{Code: 250, Message: greeting})
+	for _, ext := range caps {
+		replies = append(replies, Reply{Code: 250, Message: ext})
+	}
+	return c.WriteReplies(replies...)
+}
@@ -0,0 +1,60 @@
+package sasl
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type cramMD5Mechanism struct {
+	challenge string
+	auth      SecretAuthenticator
+	username  string
+	done      bool
+}
+
+// NewCRAMMD5 returns a Mechanism implementing AUTH CRAM-MD5 (RFC 2195).
+// challenge is the server challenge string sent verbatim to the client,
+// conventionally "<process-id.timestamp@hostname>".
+func NewCRAMMD5(challenge string, auth SecretAuthenticator) Mechanism {
+	return &cramMD5Mechanism{challenge: challenge, auth: auth}
+}
+
+func (m *cramMD5Mechanism) Next(response []byte) (challenge []byte, done bool, err error) {
+	if m.done {
+		return nil, true, errors.New("sasl: CRAM-MD5 exchange already complete")
+	}
+	if response == nil {
+		return []byte(m.challenge), false, nil
+	}
+	m.done = true
+
+	fields := strings.SplitN(string(response), " ", 2)
+	if len(fields) != 2 {
+		return nil, true, fmt.Errorf("sasl: malformed CRAM-MD5 response")
+	}
+	username, digest := fields[0], fields[1]
+
+	secret, err := m.auth(username)
+	if err != nil {
+		return nil, true, err
+	}
+
+	mac := hmac.New(md5.New, []byte(secret))
+	mac.Write([]byte(m.challenge))
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(digest)) {
+		return nil, true, ErrAuthFailed
+	}
+
+	m.username = username
+	return nil, true, nil
+}
+
+func (m *cramMD5Mechanism) Identity() string {
+	return m.username
+}
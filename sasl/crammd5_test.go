@@ -0,0 +1,64 @@
+package sasl
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"encoding/hex"
+	"testing"
+)
+
+func TestCRAMMD5Success(t *testing.T) {
+	const challenge = "<1234.1700000000@localhost>"
+	auth := func(username string) (string, error) {
+		if username == "alice" {
+			return "secret", nil
+		}
+		return "", ErrAuthFailed
+	}
+	m := NewCRAMMD5(challenge, auth)
+
+	c, done, err := m.Next(nil)
+	if err != nil || done || string(c) != challenge {
+		t.Fatalf("got challenge=%q done=%v err=%v", c, done, err)
+	}
+
+	mac := hmac.New(md5.New, []byte("secret"))
+	mac.Write([]byte(challenge))
+	digest := hex.EncodeToString(mac.Sum(nil))
+
+	_, done, err = m.Next([]byte("alice " + digest))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !done {
+		t.Fatalf("expected exchange to complete")
+	}
+	if id := m.(Identifier).Identity(); id != "alice" {
+		t.Fatalf("got identity %q", id)
+	}
+}
+
+func TestCRAMMD5WrongDigest(t *testing.T) {
+	m := NewCRAMMD5("<challenge>", func(username string) (string, error) {
+		return "secret", nil
+	})
+	m.Next(nil)
+
+	_, _, err := m.Next([]byte("alice deadbeef"))
+	if err != ErrAuthFailed {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+}
+
+func TestCRAMMD5MalformedResponse(t *testing.T) {
+	m := NewCRAMMD5("<challenge>", func(username string) (string, error) {
+		t.Fatal("SecretAuthenticator should not be called on a malformed response")
+		return "", nil
+	})
+	m.Next(nil)
+
+	_, done, err := m.Next([]byte("no-space-in-response"))
+	if err == nil || !done {
+		t.Fatalf("expected a terminal error, got done=%v err=%v", done, err)
+	}
+}
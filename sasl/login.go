@@ -0,0 +1,58 @@
+package sasl
+
+import "errors"
+
+type loginState int
+
+const (
+	loginUsername loginState = iota
+	loginPassword
+	loginDone
+	loginComplete
+)
+
+type loginMechanism struct {
+	auth     Authenticator
+	state    loginState
+	username string
+	identity string
+}
+
+// NewLogin returns a Mechanism implementing the (non-standard but
+// widely deployed) AUTH LOGIN exchange: a "Username:" challenge
+// followed by a "Password:" challenge.
+func NewLogin(auth Authenticator) Mechanism {
+	return &loginMechanism{auth: auth}
+}
+
+func (m *loginMechanism) Next(response []byte) (challenge []byte, done bool, err error) {
+	switch m.state {
+	case loginUsername:
+		if response != nil {
+			// The client supplied its username as the initial response.
+			m.username = string(response)
+			m.state = loginDone
+			return []byte("Password:"), false, nil
+		}
+		m.state = loginPassword
+		return []byte("Username:"), false, nil
+	case loginPassword:
+		m.username = string(response)
+		m.state = loginDone
+		return []byte("Password:"), false, nil
+	case loginDone:
+		m.state = loginComplete
+		identity, err := m.auth("", m.username, string(response))
+		if err != nil {
+			return nil, true, err
+		}
+		m.identity = identity
+		return nil, true, nil
+	default:
+		return nil, true, errors.New("sasl: LOGIN exchange already complete")
+	}
+}
+
+func (m *loginMechanism) Identity() string {
+	return m.identity
+}
@@ -0,0 +1,66 @@
+package sasl
+
+import "testing"
+
+func TestLoginChallengeFirst(t *testing.T) {
+	auth := func(identity, username, password string) (string, error) {
+		if username == "alice" && password == "secret" {
+			return username, nil
+		}
+		return "", ErrAuthFailed
+	}
+	m := NewLogin(auth)
+
+	challenge, done, err := m.Next(nil)
+	if err != nil || done || string(challenge) != "Username:" {
+		t.Fatalf("got challenge=%q done=%v err=%v", challenge, done, err)
+	}
+
+	challenge, done, err = m.Next([]byte("alice"))
+	if err != nil || done || string(challenge) != "Password:" {
+		t.Fatalf("got challenge=%q done=%v err=%v", challenge, done, err)
+	}
+
+	_, done, err = m.Next([]byte("secret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !done {
+		t.Fatalf("expected exchange to complete")
+	}
+	if id := m.(Identifier).Identity(); id != "alice" {
+		t.Fatalf("got identity %q", id)
+	}
+}
+
+func TestLoginInitialResponse(t *testing.T) {
+	// AUTH LOGIN <initial-response> supplies the username up front, so
+	// the server should skip straight to the password challenge.
+	auth := func(identity, username, password string) (string, error) {
+		return username, nil
+	}
+	m := NewLogin(auth)
+
+	challenge, done, err := m.Next([]byte("alice"))
+	if err != nil || done || string(challenge) != "Password:" {
+		t.Fatalf("got challenge=%q done=%v err=%v", challenge, done, err)
+	}
+
+	_, done, err = m.Next([]byte("secret"))
+	if err != nil || !done {
+		t.Fatalf("got done=%v err=%v", done, err)
+	}
+}
+
+func TestLoginBadCredentials(t *testing.T) {
+	m := NewLogin(func(identity, username, password string) (string, error) {
+		return "", ErrAuthFailed
+	})
+	m.Next(nil)
+	m.Next([]byte("alice"))
+
+	_, _, err := m.Next([]byte("wrong"))
+	if err != ErrAuthFailed {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+}
@@ -0,0 +1,41 @@
+package sasl
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type plainMechanism struct {
+	auth     Authenticator
+	identity string
+}
+
+// NewPlain returns a Mechanism implementing AUTH PLAIN (RFC 4616): a
+// single client message of the form "identity\x00username\x00password".
+func NewPlain(auth Authenticator) Mechanism {
+	return &plainMechanism{auth: auth}
+}
+
+func (m *plainMechanism) Next(response []byte) (challenge []byte, done bool, err error) {
+	if response == nil {
+		// PLAIN has no server challenge; prompt for the single response.
+		return []byte{}, false, nil
+	}
+
+	parts := bytes.SplitN(response, []byte{0}, 3)
+	if len(parts) != 3 {
+		return nil, true, fmt.Errorf("sasl: malformed PLAIN response")
+	}
+
+	identity, username, password := string(parts[0]), string(parts[1]), string(parts[2])
+	authIdentity, err := m.auth(identity, username, password)
+	if err != nil {
+		return nil, true, err
+	}
+	m.identity = authIdentity
+	return nil, true, nil
+}
+
+func (m *plainMechanism) Identity() string {
+	return m.identity
+}
@@ -0,0 +1,55 @@
+package sasl
+
+import "testing"
+
+func TestPlainSuccess(t *testing.T) {
+	auth := func(identity, username, password string) (string, error) {
+		if username == "alice" && password == "secret" {
+			return username, nil
+		}
+		return "", ErrAuthFailed
+	}
+	m := NewPlain(auth)
+
+	challenge, done, err := m.Next(nil)
+	if err != nil || done {
+		t.Fatalf("initial Next: challenge=%q done=%v err=%v", challenge, done, err)
+	}
+
+	_, done, err = m.Next([]byte("\x00alice\x00secret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !done {
+		t.Fatalf("expected exchange to complete in one round trip")
+	}
+	if id := m.(Identifier).Identity(); id != "alice" {
+		t.Fatalf("got identity %q", id)
+	}
+}
+
+func TestPlainBadCredentials(t *testing.T) {
+	auth := func(identity, username, password string) (string, error) {
+		return "", ErrAuthFailed
+	}
+	m := NewPlain(auth)
+	m.Next(nil)
+
+	_, _, err := m.Next([]byte("\x00alice\x00wrong"))
+	if err != ErrAuthFailed {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+}
+
+func TestPlainMalformedResponse(t *testing.T) {
+	m := NewPlain(func(identity, username, password string) (string, error) {
+		t.Fatal("Authenticator should not be called on a malformed response")
+		return "", nil
+	})
+	m.Next(nil)
+
+	_, done, err := m.Next([]byte("not-enough-nuls"))
+	if err == nil || !done {
+		t.Fatalf("expected a terminal error, got done=%v err=%v", done, err)
+	}
+}
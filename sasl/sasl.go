@@ -0,0 +1,39 @@
+// Package sasl implements the server side of the SASL mechanisms used
+// to satisfy SMTP AUTH (RFC 4954): PLAIN, LOGIN, CRAM-MD5, and XOAUTH2.
+package sasl
+
+import "errors"
+
+// ErrAuthFailed is returned by a Mechanism when the credentials supplied
+// by the client are rejected.
+var ErrAuthFailed = errors.New("sasl: authentication failed")
+
+// Mechanism drives one SASL mechanism's server-side challenge/response
+// exchange, one step at a time. Next is called with the client's most
+// recent response — nil on the first call unless the client supplied an
+// initial response alongside AUTH — and returns the next challenge to
+// send the client, or done=true once the exchange has a final result.
+type Mechanism interface {
+	Next(response []byte) (challenge []byte, done bool, err error)
+}
+
+// Identifier is implemented by Mechanisms that can report the username
+// they authenticated once their exchange has completed successfully.
+type Identifier interface {
+	Identity() string
+}
+
+// Authenticator verifies an identity/username/password triple, as
+// extracted by the PLAIN and LOGIN mechanisms, and returns the
+// authenticated identity on success. identity is the optional
+// authorization identity (the "authzid"); it is empty for LOGIN.
+type Authenticator func(identity, username, password string) (string, error)
+
+// SecretAuthenticator returns the plaintext shared secret for username,
+// used by CRAM-MD5 to compute the expected digest without ever putting
+// the secret itself on the wire.
+type SecretAuthenticator func(username string) (secret string, err error)
+
+// TokenAuthenticator verifies an OAuth2 bearer token presented for
+// username, as extracted by the XOAUTH2 mechanism.
+type TokenAuthenticator func(username, token string) (string, error)
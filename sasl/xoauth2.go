@@ -0,0 +1,61 @@
+package sasl
+
+import (
+	"bytes"
+	"errors"
+)
+
+type xoauth2Mechanism struct {
+	auth     TokenAuthenticator
+	username string
+	done     bool
+}
+
+// NewXOAUTH2 returns a Mechanism implementing AUTH XOAUTH2, Google's
+// OAuth2 bridge for SMTP/IMAP AUTH. The client's single response has the
+// form "user=<email>\x01auth=Bearer <token>\x01\x01".
+func NewXOAUTH2(auth TokenAuthenticator) Mechanism {
+	return &xoauth2Mechanism{auth: auth}
+}
+
+func (m *xoauth2Mechanism) Next(response []byte) (challenge []byte, done bool, err error) {
+	if m.done {
+		return nil, true, errors.New("sasl: XOAUTH2 exchange already complete")
+	}
+	if response == nil {
+		return []byte{}, false, nil
+	}
+	m.done = true
+
+	username, token, err := parseXOAUTH2(response)
+	if err != nil {
+		return nil, true, err
+	}
+
+	identity, err := m.auth(username, token)
+	if err != nil {
+		return nil, true, err
+	}
+
+	m.username = identity
+	return nil, true, nil
+}
+
+func (m *xoauth2Mechanism) Identity() string {
+	return m.username
+}
+
+func parseXOAUTH2(response []byte) (username, token string, err error) {
+	for _, field := range bytes.Split(response, []byte{1}) {
+		switch {
+		case bytes.HasPrefix(field, []byte("user=")):
+			username = string(field[len("user="):])
+		case bytes.HasPrefix(field, []byte("auth=Bearer ")):
+			token = string(field[len("auth=Bearer "):])
+		}
+	}
+	if username == "" || token == "" {
+		return "", "", errors.New("sasl: malformed XOAUTH2 response")
+	}
+	return username, token, nil
+}
@@ -0,0 +1,56 @@
+package sasl
+
+import "testing"
+
+func TestXOAUTH2Success(t *testing.T) {
+	auth := func(username, token string) (string, error) {
+		if username == "alice@example.com" && token == "vF9dft4qmTc2Nvb3RlckBhdHRhdmlzdGEuY29tCg" {
+			return username, nil
+		}
+		return "", ErrAuthFailed
+	}
+	m := NewXOAUTH2(auth)
+
+	challenge, done, err := m.Next(nil)
+	if err != nil || done || len(challenge) != 0 {
+		t.Fatalf("got challenge=%q done=%v err=%v", challenge, done, err)
+	}
+
+	response := "user=alice@example.com\x01auth=Bearer vF9dft4qmTc2Nvb3RlckBhdHRhdmlzdGEuY29tCg\x01\x01"
+	_, done, err = m.Next([]byte(response))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !done {
+		t.Fatalf("expected exchange to complete")
+	}
+	if id := m.(Identifier).Identity(); id != "alice@example.com" {
+		t.Fatalf("got identity %q", id)
+	}
+}
+
+func TestXOAUTH2BadToken(t *testing.T) {
+	m := NewXOAUTH2(func(username, token string) (string, error) {
+		return "", ErrAuthFailed
+	})
+	m.Next(nil)
+
+	response := "user=alice@example.com\x01auth=Bearer bad\x01\x01"
+	_, _, err := m.Next([]byte(response))
+	if err != ErrAuthFailed {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+}
+
+func TestXOAUTH2MalformedResponse(t *testing.T) {
+	m := NewXOAUTH2(func(username, token string) (string, error) {
+		t.Fatal("TokenAuthenticator should not be called on a malformed response")
+		return "", nil
+	})
+	m.Next(nil)
+
+	_, done, err := m.Next([]byte("user=alice@example.com\x01\x01"))
+	if err == nil || !done {
+		t.Fatalf("expected a terminal error, got done=%v err=%v", done, err)
+	}
+}
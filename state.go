@@ -0,0 +1,121 @@
+package smtpd
+
+import "strings"
+
+// State represents where a Conn sits in the SMTP command sequence, so
+// that commands issued out of order (RCPT before MAIL, DATA with no
+// recipients, and so on) can be rejected before they reach a handler.
+type State int
+
+const (
+	// StateNew is the state of a freshly accepted connection, before EHLO/HELO.
+	StateNew State = iota
+	// StateGreeted follows a successful EHLO/HELO.
+	StateGreeted
+	// StateMail follows a successful MAIL FROM.
+	StateMail
+	// StateRcpt follows at least one RCPT TO command, whether or not a
+	// handler accepted the recipient (see Transition).
+	StateRcpt
+	// StateData is entered for the duration of the DATA command.
+	StateData
+	// StateAuth follows a successful AUTH.
+	StateAuth
+)
+
+// String implements fmt.Stringer for State.
+func (s State) String() string {
+	switch s {
+	case StateNew:
+		return "StateNew"
+	case StateGreeted:
+		return "StateGreeted"
+	case StateMail:
+		return "StateMail"
+	case StateRcpt:
+		return "StateRcpt"
+	case StateData:
+		return "StateData"
+	case StateAuth:
+		return "StateAuth"
+	default:
+		return "StateUnknown"
+	}
+}
+
+// stateTransitions lists, for each verb that is order-sensitive, the
+// states from which it may legally be issued. Verbs with no entry here
+// (NOOP, QUIT, VRFY, ...) are allowed in any state.
+var stateTransitions = map[string][]State{
+	"HELO":     {StateNew, StateGreeted, StateMail, StateRcpt, StateAuth},
+	"EHLO":     {StateNew, StateGreeted, StateMail, StateRcpt, StateAuth},
+	"STARTTLS": {StateGreeted, StateAuth},
+	"AUTH":     {StateGreeted},
+	"MAIL":     {StateGreeted, StateAuth},
+	"RCPT":     {StateMail, StateRcpt},
+	"DATA":     {StateRcpt},
+	"RSET":     {StateNew, StateGreeted, StateMail, StateRcpt, StateData, StateAuth},
+}
+
+// Transition validates that verb is legal in the connection's current
+// state and, if so, advances to the state that follows it. It returns
+// ErrBadSequence when the verb arrives out of order, so the caller can
+// reply with a 503.
+//
+// Transition fires as soon as a verb is parsed, before its handler
+// decides whether to act on it, so StateRcpt only means "a RCPT verb
+// was parsed" — not "a recipient was accepted." DATA is gated
+// separately on c.ToAddr being non-empty, so a RCPT TO a handler
+// rejects (and so never appends to ToAddr) can't be followed by a DATA
+// with zero accepted recipients.
+func (c *Conn) Transition(verb string) error {
+	verb = strings.ToUpper(verb)
+	allowed, known := stateTransitions[verb]
+	if !known {
+		return nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if verb == "DATA" && len(c.ToAddr) == 0 {
+		return ErrBadSequence
+	}
+
+	for _, s := range allowed {
+		if c.state == s {
+			c.state = nextState(verb, c.state)
+			return nil
+		}
+	}
+	return ErrBadSequence
+}
+
+// State reports the connection's current State.
+func (c *Conn) State() State {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.state
+}
+
+// nextState returns the state that follows a successful verb, given the
+// state it was issued from.
+func nextState(verb string, from State) State {
+	switch verb {
+	case "HELO", "EHLO":
+		return StateGreeted
+	case "MAIL":
+		return StateMail
+	case "RCPT":
+		return StateRcpt
+	case "DATA":
+		return StateData
+	case "RSET":
+		if from == StateNew || from == StateAuth {
+			return from
+		}
+		return StateGreeted
+	default:
+		return from
+	}
+}
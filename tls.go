@@ -0,0 +1,43 @@
+package smtpd
+
+import (
+	"crypto/tls"
+)
+
+// StartTLS performs the STARTTLS handshake described in RFC 3207: it
+// announces readiness, upgrades the underlying net.Conn to TLS, and
+// clears session state so the client is required to EHLO again before
+// issuing any further transaction commands.
+//
+// Any plaintext bytes still sitting in the read buffer when the
+// handshake begins are discarded, never replayed as post-TLS commands:
+// RFC 3207 §4 requires this to close off the STARTTLS plaintext
+// command injection attack (CVE-2011-0411).
+func (c *Conn) StartTLS(cfg *tls.Config) error {
+	if c.IsTLS {
+		return ErrTLSAlreadyNegotiated
+	}
+
+	if err := c.WriteSMTP(220, "Ready to start TLS"); err != nil {
+		return err
+	}
+
+	tlsConn := tls.Server(c.Conn, cfg)
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+
+	c.Conn = tlsConn
+	c.IsTLS = true
+	c.rebuildReader(c)
+	// Mark the reader as built so a later reader() call doesn't clobber it.
+	c.asReader.Do(func() {})
+
+	// The client must re-EHLO before anything else, per RFC 3207 §4.2.
+	c.Reset()
+	c.lock.Lock()
+	c.state = StateNew
+	c.lock.Unlock()
+
+	return nil
+}